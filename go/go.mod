@@ -0,0 +1,3 @@
+module github.com/WorldWeatherOnline/weather-api-docs/go
+
+go 1.24
@@ -0,0 +1,19 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+// Terminal detection for macOS and the BSDs, where a terminal answers
+// TIOCGETA.
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func init() {
+	platformIsTerminal = func(fd uintptr) bool {
+		var t syscall.Termios
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGETA, uintptr(unsafe.Pointer(&t)))
+		return errno == 0
+	}
+}
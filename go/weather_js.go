@@ -0,0 +1,172 @@
+//go:build js && wasm
+
+// Browser build of the weather client, for in-page widgets that want the
+// same parsing and summaries as the command line:
+//
+//   GOOS=js GOARCH=wasm go build -o weather.wasm .
+//   cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" .
+//
+// The module registers a global wwo object whose calls return Promises:
+//
+//   const go = new Go();
+//   const { instance } = await WebAssembly.instantiateStreaming(fetch("weather.wasm"), go.importObject);
+//   go.run(instance);
+//   wwo.configure({ key: "your_key", lang: "de" });
+//   const rec = await wwo.forecast("London", 3);   // same shape as "record"
+//   console.log(rec.current.temp_c, rec.forecast[0].rain_chance);
+//   wwo.condition(rec.current.weather_code).severity;  // synchronous
+//
+// Requests go through the browser's fetch, so the API (or the serve
+// subcommand, via baseURL) must allow the page's origin. Nothing here calls
+// os.Exit: errors reject the Promise and the module keeps running.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"syscall/js"
+	"time"
+
+	"github.com/WorldWeatherOnline/weather-api-docs/go/wwo"
+)
+
+// wasmKey is the API key set with wwo.configure; there is no environment
+// or keychain to read one from in a browser.
+var wasmKey string
+
+func init() {
+	platformMain = runWASM
+	// net/http only uses the Fetch API when the transport has no custom
+	// dialer, and a page can't open sockets or read proxy settings anyway.
+	sharedTransport.DialContext = nil
+	sharedTransport.Proxy = nil
+	// No cache directory either: keep responses for the page's lifetime.
+	responseStore = newMemoryStore()
+	trackUsage = false
+}
+
+// runWASM exposes the client to JavaScript and blocks so the callbacks
+// stay alive.
+func runWASM() {
+	wwo := js.Global().Get("Object").New()
+	wwo.Set("configure", js.FuncOf(jsConfigure))
+	wwo.Set("current", jsPromise(func(args []js.Value) (any, error) {
+		return jsRecord(argString(args, 0), 1)
+	}))
+	wwo.Set("forecast", jsPromise(func(args []js.Value) (any, error) {
+		days := 3
+		if len(args) > 1 && args[1].Type() == js.TypeNumber {
+			days = args[1].Int()
+		}
+		if days < 1 || days > 14 {
+			return nil, fmt.Errorf("days must be between 1 and 14")
+		}
+		return jsRecord(argString(args, 0), days)
+	}))
+	wwo.Set("search", jsPromise(func(args []js.Value) (any, error) {
+		if wasmKey == "" {
+			return nil, errNoWASMKey
+		}
+		ctx, cancel := withDeadline(context.Background())
+		defer cancel()
+		return searchLocations(ctx, argString(args, 0), 10, wasmKey)
+	}))
+	wwo.Set("condition", js.FuncOf(jsCondition))
+	js.Global().Set("wwo", wwo)
+	select {}
+}
+
+var errNoWASMKey = errors.New("no API key: call wwo.configure({key: ...}) first")
+
+// jsConfigure implements wwo.configure({key, lang, baseURL}); omitted
+// fields keep their current value.
+func jsConfigure(this js.Value, args []js.Value) any {
+	if len(args) == 0 || args[0].Type() != js.TypeObject {
+		return nil
+	}
+	opts := args[0]
+	if v := opts.Get("key"); v.Type() == js.TypeString {
+		wasmKey = v.String()
+	}
+	if v := opts.Get("lang"); v.Type() == js.TypeString {
+		uiLang = v.String()
+	}
+	if v := opts.Get("baseURL"); v.Type() == js.TypeString {
+		apiBaseURL = v.String()
+	}
+	return nil
+}
+
+// jsCondition implements wwo.condition(code): the wwo.Condition entry for a
+// weatherCode, with its day and night icons drawn in the current style.
+func jsCondition(this js.Value, args []js.Value) any {
+	code := 0
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		code = args[0].Int()
+	}
+	wc, known := wwo.Condition(code)
+	c := WeatherCondition{wc}
+	return map[string]any{
+		"code":        c.Code,
+		"description": c.Description,
+		"icon":        c.Icon,
+		"class":       c.Class,
+		"severity":    c.Severity,
+		"day_icon":    c.IconAt(false),
+		"night_icon":  c.IconAt(true),
+		"known":       known,
+	}
+}
+
+func jsRecord(location string, days int) (Record, error) {
+	if wasmKey == "" {
+		return Record{}, errNoWASMKey
+	}
+	if location == "" {
+		return Record{}, fmt.Errorf("location is required")
+	}
+	ctx, cancel := withDeadline(context.Background())
+	defer cancel()
+	data, err := fetchWeather(ctx, location, days, 3, wasmKey)
+	if err != nil {
+		return Record{}, err
+	}
+	cur, forecast := summarize(data, locationLabel(data, location))
+	return Record{Fetched: time.Now().UTC(), Query: location, Current: cur, Forecast: forecast}, nil
+}
+
+// jsPromise wraps fn as a JavaScript function returning a Promise that
+// resolves to fn's result as a plain object. fn runs on its own goroutine,
+// since a blocking fetch must not run on the event loop's callback.
+func jsPromise(fn func(args []js.Value) (any, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		handler := js.FuncOf(func(this js.Value, p []js.Value) any {
+			resolve, reject := p[0], p[1]
+			go func() {
+				v, err := fn(args)
+				var b []byte
+				if err == nil {
+					b, err = json.Marshal(v)
+				}
+				if err != nil {
+					reject.Invoke(js.Global().Get("Error").New(err.Error()))
+					return
+				}
+				resolve.Invoke(js.Global().Get("JSON").Call("parse", string(b)))
+			}()
+			return nil
+		})
+		defer handler.Release()
+		return js.Global().Get("Promise").New(handler)
+	})
+}
+
+func argString(args []js.Value, i int) string {
+	if i < len(args) && args[i].Type() == js.TypeString {
+		return args[i].String()
+	}
+	return ""
+}
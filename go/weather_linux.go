@@ -0,0 +1,19 @@
+//go:build linux
+
+// Terminal detection for Linux, where a terminal answers TCGETS. It is
+// built in by the _linux suffix alongside weather.go.
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func init() {
+	platformIsTerminal = func(fd uintptr) bool {
+		var t syscall.Termios
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS, uintptr(unsafe.Pointer(&t)))
+		return errno == 0
+	}
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WorldWeatherOnline/weather-api-docs/go/wwotest"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files in testdata from the current output")
+
+// fakeAPI points the client at a wwotest server for the rest of the test,
+// with the config, response cache and call counter kept out of the user's
+// files.
+func fakeAPI(t *testing.T) *wwotest.Server {
+	t.Helper()
+	srv := wwotest.NewServer(t)
+	dir := t.TempDir()
+	t.Setenv("WWO_CONFIG", filepath.Join(dir, "config.json"))
+	t.Setenv("XDG_CACHE_HOME", dir)
+
+	oldURL, oldClient, oldStore, oldTrack := apiBaseURL, httpClient, responseStore, trackUsage
+	apiBaseURL, httpClient, responseStore, trackUsage = srv.URL, srv.Client(), newMemoryStore(), false
+	t.Cleanup(func() {
+		apiBaseURL, httpClient, responseStore, trackUsage = oldURL, oldClient, oldStore, oldTrack
+	})
+	return srv
+}
+
+func TestFetchWeather(t *testing.T) {
+	srv := fakeAPI(t)
+	data, err := fetchWeather(context.Background(), "London", 3, 3, "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(data.Data.Weather); got != 3 {
+		t.Errorf("got %d days, want 3", got)
+	}
+	if got := locationLabel(data, "London"); got != "London, United Kingdom" {
+		t.Errorf("location = %q", got)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	for k, want := range map[string]string{"q": "London", "key": "test-key", "num_of_days": "3", "tp": "3"} {
+		if got := reqs[0].Get(k); got != want {
+			t.Errorf("request %s = %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestFetchWeatherAPIError(t *testing.T) {
+	fakeAPI(t)
+	_, err := fetchWeather(context.Background(), wwotest.ErrorLocation, 1, 24, "test-key")
+	if err == nil || !strings.Contains(err.Error(), "Unable to find") {
+		t.Fatalf("err = %v, want the API's error message", err)
+	}
+}
+
+// The placeholder key is a usage error to return, not a reason to exit, so
+// fatal reports it like any other.
+func TestPlaceholderKey(t *testing.T) {
+	srv := fakeAPI(t)
+	_, err := fetchWeather(context.Background(), "London", 1, 24, "your_api_key_here")
+	if code, status := errorClass(err); code != "usage" || status != exitUsage {
+		t.Errorf("errorClass(%v) = %s, %d, want usage, %d", err, code, status, exitUsage)
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("made %d requests with the placeholder key", n)
+	}
+}
+
+// The fixtures' weather codes must agree with their descriptions, or the
+// icons and classes drawn from the codes contradict the text beside them.
+func TestFixtureConditions(t *testing.T) {
+	fakeAPI(t)
+	data, err := fetchWeather(context.Background(), "London", 3, 3, "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, day := range data.Data.Weather {
+		for _, h := range day.Hourly {
+			desc := firstValue(h.WeatherDesc)
+			if got, want := h.Condition().Class, conditionByDescription(desc).Class; got != want {
+				t.Errorf("%s %s: code %s is class %q, description %q is %q", day.Date, h.Time, h.WeatherCode, got, desc, want)
+			}
+		}
+	}
+}
+
+// /dev/null is a character device, and stdin under cron and systemd, but
+// nobody is there to answer a prompt on it.
+func TestIsTerminalDevNull(t *testing.T) {
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Errorf("isTerminal(%s) = true", os.DevNull)
+	}
+}
+
+// -deadline bounds each operation, so a daemon's later requests get the
+// whole of it too, however long after startup they come.
+func TestDeadlinePerOperation(t *testing.T) {
+	fakeAPI(t)
+	old := deadline
+	deadline = 100 * time.Millisecond
+	t.Cleanup(func() { deadline = old })
+
+	for i := range 2 {
+		if i > 0 {
+			time.Sleep(2 * deadline)
+		}
+		ctx, cancel := withDeadline(context.Background())
+		_, err := fetchWeather(ctx, "London", 1, 24, "test-key")
+		cancel()
+		if err != nil {
+			t.Fatalf("operation %d: %v", i+1, err)
+		}
+	}
+}
+
+// fixtureView is the London fixture as the default command would render
+// it, with a frost warning to show the banner.
+func fixtureView(t *testing.T) View {
+	t.Helper()
+	body, err := wwotest.Fixture("weather")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data WeatherResponse
+	if err := json.Unmarshal(body, &data); err != nil {
+		t.Fatal(err)
+	}
+	deriveWeather(&data)
+	frost := 6.0
+	return View{
+		Location: locationLabel(&data, "London"),
+		Current:  data.Data.CurrentCondition[0],
+		Forecast: data.Data.Weather,
+		Width:    80,
+		Template: "{{.Location}}: {{.Current.TempC}}°C, {{.Current.Description}}",
+		Warnings: derivedWarnings(WarningConfig{FrostC: &frost}, data.Data.Weather),
+	}
+}
+
+// volatile matches the parts of a feed stamped with the time of writing.
+var volatile = regexp.MustCompile(`DTSTAMP:\d{8}T\d{6}Z|<updated>[^<]*</updated>`)
+
+// The layouts are a contract with the scripts that parse them (see
+// outputVersion): every format, at every output version, must match its
+// golden file in testdata. After an intended change, rewrite them with
+// go test -run Golden -update and review the diff.
+func TestRenderGolden(t *testing.T) {
+	oldLang, oldIcons, oldColor, oldUnits, oldPlain, oldWidth, oldVersion := uiLang, iconStyle, colorEnabled, units, plainOutput, outputWidth, outputVersion
+	t.Cleanup(func() {
+		uiLang, iconStyle, colorEnabled, units, plainOutput, outputWidth, outputVersion = oldLang, oldIcons, oldColor, oldUnits, oldPlain, oldWidth, oldVersion
+	})
+	uiLang, iconStyle, colorEnabled, units, plainOutput, outputWidth = "en", "emoji", false, "", false, 80
+
+	v := fixtureView(t)
+	for version := 1; version <= latestOutputVersion; version++ {
+		outputVersion = version
+		for _, name := range strings.Split(rendererNames(), ", ") {
+			t.Run(fmt.Sprintf("%s/v%d", name, version), func(t *testing.T) {
+				r, _ := lookupRenderer(name)
+				var buf bytes.Buffer
+				if err := renderView(r, &buf, v); err != nil {
+					t.Fatal(err)
+				}
+				got := volatile.ReplaceAll(buf.Bytes(), []byte("<volatile>"))
+				golden := filepath.Join("testdata", fmt.Sprintf("%s.v%d.golden", name, version))
+				if *update {
+					if err := os.MkdirAll("testdata", 0o755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(golden, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (create it with -update)", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("output differs from %s; if the change is intended, bump latestOutputVersion where scripts would break and rerun with -update\ngot:\n%s\nwant:\n%s", golden, got, want)
+				}
+			})
+		}
+	}
+}
+
+// Readings the API left out stay unknown in the summaries, while a real 0
+// is kept.
+func TestSummarizeUnknown(t *testing.T) {
+	data := &WeatherResponse{Data: WeatherData{
+		CurrentCondition: []CurrentCondition{{TempC: "0", Humidity: unknownValue, Winddir16Point: unknownValue}},
+		Weather: []DayForecast{{Date: "2026-10-14", MaxTempC: unknownValue, MinTempC: "3", Hourly: []HourlyData{
+			{Chanceofrain: unknownValue, WindspeedKmph: "12", PrecipMM: unknownValue},
+		}}},
+	}}
+	cur, days := summarize(data, "Nowhere")
+	if cur.TempC == nil || *cur.TempC != 0 {
+		t.Errorf("TempC = %v, want 0", cur.TempC)
+	}
+	if cur.Humidity != nil || cur.RainChance != nil || cur.WindDir != "" {
+		t.Errorf("unknown readings = %v, %v, %q, want nil, nil and empty", cur.Humidity, cur.RainChance, cur.WindDir)
+	}
+	b, err := json.Marshal(cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); !strings.Contains(s, `"temp_c":0`) || strings.Contains(s, `"humidity"`) || strings.Contains(s, `"wind_dir"`) {
+		t.Errorf("JSON = %s, want temp_c 0 and no humidity or wind_dir", s)
+	}
+
+	d := days[0]
+	if d.TempMaxC != nil || d.RainChance != nil || d.PrecipMM != nil {
+		t.Errorf("unknown day readings = %v, %v, %v, want nil", d.TempMaxC, d.RainChance, d.PrecipMM)
+	}
+	if d.TempMinC == nil || *d.TempMinC != 3 || d.WindKmph == nil || *d.WindKmph != 12 {
+		t.Errorf("day readings = %v, %v, want 3 and 12", d.TempMinC, d.WindKmph)
+	}
+}
+
+// gRPC statuses follow the classes of the command line's exit statuses.
+func TestGRPCStatus(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want int
+	}{
+		{apiError("Unable to find any matching weather location to the query submitted!"), grpcNotFound},
+		{usageError{errors.New("days must be between 1 and 14")}, grpcInvalidArgument},
+		{httpStatusError(http.StatusTooManyRequests), grpcResourceExhausted},
+		{networkError{errors.New("connection refused")}, grpcUnavailable},
+		{fmt.Errorf("London: %w", errDeadline), grpcDeadlineExceeded},
+		{errors.New("something else"), grpcUnknown},
+	} {
+		if got := grpcStatus(tt.err); got != tt.want {
+			t.Errorf("grpcStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+// A day with none of the readings an activity is scored on has no score.
+func TestScoreUnknown(t *testing.T) {
+	scores := rankDays(defaultActivities["cycling"], []DaySummary{{Date: "2026-06-01"}}, 1)
+	if s := scores[0]; s.Score != nil || s.Best {
+		t.Errorf("rankDays of an unknown day = score %v, best %v; want no score", s.Score, s.Best)
+	}
+}
+
+// The saved config holds only what was set.
+func TestConfigSaveOmitsUnset(t *testing.T) {
+	t.Setenv("WWO_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+	if err := (&Config{Units: "metric"}).save(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(os.Getenv("WWO_CONFIG"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "{\n  \"units\": \"metric\"\n}\n"; got != want {
+		t.Errorf("saved config = %q, want %q", got, want)
+	}
+}
+
+// A rule on a reading the forecast doesn't have never fires.
+func TestRuleUnknownField(t *testing.T) {
+	r, err := parseRule("temp_min < 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := DayForecast{Date: "2026-06-01", MaxTempC: "N/A", MinTempC: "N/A", Hourly: []HourlyData{{TempC: "N/A"}}}
+	if alerts := evaluateRules([]Rule{r}, []DayForecast{day}); len(alerts) != 0 {
+		t.Errorf("evaluateRules on an N/A day = %v, want none", alerts)
+	}
+}
+
+// A garden day without readings has no night low, frost or ET₀.
+func TestGardenUnknown(t *testing.T) {
+	data := &WeatherResponse{}
+	data.Data.Weather = []DayForecast{{Date: "2026-06-01", MaxTempC: "N/A", MinTempC: "N/A", Hourly: []HourlyData{{Time: "2100", TempC: "N/A", Humidity: "", WindspeedKmph: "N/A"}}}}
+	g := gardenDays(data, 2)[0]
+	if g.NightLowC != nil || g.Frost || g.ET0MM != nil {
+		t.Errorf("gardenDays of an N/A day = low %v, frost %v, ET0 %v; want all unknown", g.NightLowC, g.Frost, g.ET0MM)
+	}
+}
+
+// A wear rule on a reading that's missing doesn't hold.
+func TestWearUnknown(t *testing.T) {
+	got, err := wearAdvice(defaultWear, CurrentCondition{FeelsLikeC: "N/A"}, DayForecast{Hourly: []HourlyData{{FeelsLikeC: ""}}})
+	if err != nil || got != "" {
+		t.Errorf("wearAdvice with no readings = %q, %v; want no advice", got, err)
+	}
+}
+
+// init's key check asks the API, not the cache of an earlier search.
+func TestCheckKeysSkipsCache(t *testing.T) {
+	fakeAPI(t)
+	if _, err := searchLocations(context.Background(), "London", 1, "good-key"); err != nil {
+		t.Fatal(err)
+	}
+	reject := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer reject.Close()
+	apiBaseURL = reject.URL
+	var status httpStatusError
+	if err := checkKeys(context.Background(), "bad-key"); !errors.As(err, &status) || status != http.StatusUnauthorized {
+		t.Errorf("checkKeys of a rejected key = %v, want HTTP 401", err)
+	}
+}
+
+// The cache stands in for an API that can't be reached, not one that said no.
+func TestStaleOnlyWhenUnreachable(t *testing.T) {
+	fakeAPI(t)
+	ctx := context.Background()
+	if _, err := searchLocations(ctx, "London", 1, "good-key"); err != nil {
+		t.Fatal(err)
+	}
+	reject := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	apiBaseURL = reject.URL
+	var status httpStatusError
+	if _, err := searchLocations(ctx, "London", 1, "good-key"); !errors.As(err, &status) || status != http.StatusUnauthorized {
+		t.Errorf("search with a rejected key = %v, want HTTP 401", err)
+	}
+	reject.Close()
+	if _, err := searchLocations(ctx, "London", 1, "good-key"); err != nil {
+		t.Errorf("search with the API unreachable = %v, want the cached response", err)
+	}
+}
+
+// forecastAt leaves out what either hourly block lacks rather than
+// interpolating from 0.
+func TestForecastAtUnknown(t *testing.T) {
+	day := DayForecast{Date: "2026-06-01", Zone: time.UTC, Hourly: []HourlyData{
+		{Time: "900", TempC: "10", FeelsLikeC: "8", Humidity: "70"},
+		{Time: "1200", TempC: "14", FeelsLikeC: "", Humidity: "60"},
+	}}
+	r, err := forecastAt([]DayForecast{day}, time.Date(2026, 6, 1, 10, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.TempC != 12 || r.FeelsLikeC != nil || r.Humidity == nil || *r.Humidity != 65 {
+		t.Errorf("forecastAt = temp %v, feels %v, humidity %v; want 12, unknown, 65", r.TempC, r.FeelsLikeC, r.Humidity)
+	}
+}
+
+// Hourly blocks too many for the width thin to every third hour, the
+// coarsest step, when that fits.
+func TestMatrixThinsFromAllHours(t *testing.T) {
+	var series []HourPoint
+	for h := 0; h < 24; h++ {
+		series = append(series, HourPoint{At: time.Date(2026, 6, 1, h, 0, 0, 0, time.UTC), TempC: 15})
+	}
+	var b bytes.Buffer
+	displayMatrix(&b, series, 56)
+	header := strings.Fields(strings.Split(strings.TrimSpace(b.String()), "\n")[2])
+	want := []string{"00", "03", "06", "09", "12", "15", "18", "21"}
+	if strings.Join(header, " ") != strings.Join(want, " ") {
+		t.Errorf("matrix columns at width 56 = %v, want %v\n%s", header, want, b.String())
+	}
+}
+
+// A throttled wait ends when its context does.
+func TestLimiterWaitCancel(t *testing.T) {
+	var b tokenBucket
+	ctx := context.Background()
+	if err := b.wait(ctx, 0.01, 1); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := b.wait(ctx, 0.01, 1); !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > time.Second {
+		t.Errorf("wait = %v after %v, want the context's deadline", err, time.Since(start))
+	}
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+// Daemon signals for Unix, where syscall names SIGHUP and SIGUSR1 for
+// every architecture. Windows and the browser have neither, and leave
+// platformDaemonSignals nil.
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+func init() {
+	platformDaemonSignals = func() (hup, usr1 os.Signal) {
+		return syscall.SIGHUP, syscall.SIGUSR1
+	}
+}
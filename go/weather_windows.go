@@ -0,0 +1,195 @@
+//go:build windows
+
+// Windows Service Control Manager support for serve and grpc-serve, the
+// console width the tables fit to, and telling the console from NUL. The
+// _windows suffix builds it in alongside weather.go:
+//
+//   go build -o weather.exe .
+//   weather.exe service install serve -addr :8080
+//   weather.exe service start
+//
+// The SCM starts the program as "weather service -name N run serve ...",
+// which hands the serve command to the dispatcher below. Stop and shutdown
+// requests end it the way SIGTERM does elsewhere.
+
+package main
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32                         = syscall.NewLazyDLL("advapi32.dll")
+	procStartServiceCtrlDispatcherW  = advapi32.NewProc("StartServiceCtrlDispatcherW")
+	procRegisterServiceCtrlHandlerEx = advapi32.NewProc("RegisterServiceCtrlHandlerExW")
+	procSetServiceStatus             = advapi32.NewProc("SetServiceStatus")
+
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+	procGetConsoleMode             = kernel32.NewProc("GetConsoleMode")
+)
+
+const (
+	serviceWin32OwnProcess = 0x10
+
+	serviceStopped      = 1
+	serviceStartPending = 2
+	serviceStopPending  = 3
+	serviceRunning      = 4
+
+	serviceControlStop        = 1
+	serviceControlInterrogate = 4
+	serviceControlShutdown    = 5
+
+	serviceAcceptStop     = 1
+	serviceAcceptShutdown = 4
+)
+
+// serviceStatus is SERVICE_STATUS.
+type serviceStatus struct {
+	ServiceType             uint32
+	CurrentState            uint32
+	ControlsAccepted        uint32
+	Win32ExitCode           uint32
+	ServiceSpecificExitCode uint32
+	CheckPoint              uint32
+	WaitHint                uint32
+}
+
+// serviceTableEntry is SERVICE_TABLE_ENTRYW.
+type serviceTableEntry struct {
+	name *uint16
+	proc uintptr
+}
+
+var (
+	svcName    *uint16
+	svcHandle  uintptr
+	svcStatus  = serviceStatus{ServiceType: serviceWin32OwnProcess}
+	svcCommand []string
+)
+
+func init() {
+	runWindowsService = runSCM
+	platformNotify = scmNotify
+	platformTermWidth = consoleWidth
+	platformIsTerminal = isConsole
+}
+
+// isConsole reports whether fd is a console; NUL is a character device
+// too, but has no console mode.
+func isConsole(fd uintptr) bool {
+	var mode uint32
+	r, _, _ := procGetConsoleMode.Call(fd, uintptr(unsafe.Pointer(&mode)))
+	return r != 0
+}
+
+// consoleScreenBufferInfo is CONSOLE_SCREEN_BUFFER_INFO.
+type consoleScreenBufferInfo struct {
+	size, cursorPosition     [2]int16
+	attributes               uint16
+	left, top, right, bottom int16
+	maximumWindowSize        [2]int16
+}
+
+// consoleWidth is the width of the console window stdout writes to, or 0.
+func consoleWidth() int {
+	var info consoleScreenBufferInfo
+	h, err := syscall.GetStdHandle(syscall.STD_OUTPUT_HANDLE)
+	if err != nil {
+		return 0
+	}
+	if r, _, _ := procGetConsoleScreenBufferInfo.Call(uintptr(h), uintptr(unsafe.Pointer(&info))); r == 0 {
+		return 0
+	}
+	return int(info.right-info.left) + 1
+}
+
+// setState reports state to the SCM.
+func setState(state uint32) {
+	svcStatus.CurrentState = state
+	svcStatus.ControlsAccepted = 0
+	if state == serviceRunning {
+		svcStatus.ControlsAccepted = serviceAcceptStop | serviceAcceptShutdown
+	}
+	if state == serviceStartPending || state == serviceStopPending {
+		svcStatus.CheckPoint++
+		svcStatus.WaitHint = 30000
+	} else {
+		svcStatus.CheckPoint, svcStatus.WaitHint = 0, 0
+	}
+	procSetServiceStatus.Call(svcHandle, uintptr(unsafe.Pointer(&svcStatus)))
+}
+
+// scmNotify maps the sd_notify states serveGracefully sends onto the SCM's.
+func scmNotify(state string) {
+	if svcHandle == 0 {
+		return
+	}
+	switch state {
+	case "READY=1":
+		setState(serviceRunning)
+	case "STOPPING=1":
+		setState(serviceStopPending)
+	}
+}
+
+// runSCM connects to the SCM and runs command as the service name. It
+// returns once the service has stopped.
+func runSCM(name string, command []string) error {
+	if len(command) == 0 {
+		return fmt.Errorf("service run: no command")
+	}
+	run := commandRunner(command[0])
+	if run == nil {
+		return fmt.Errorf("service run: unknown command %q", command[0])
+	}
+	svcCommand = command
+	n, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	svcName = n
+	table := []serviceTableEntry{{name: n, proc: syscall.NewCallback(serviceMain)}, {}}
+	if r, _, err := procStartServiceCtrlDispatcherW.Call(uintptr(unsafe.Pointer(&table[0]))); r == 0 {
+		return fmt.Errorf("service run: %w (only the Service Control Manager can start this)", err)
+	}
+	return nil
+}
+
+// serviceMain is the ServiceMain the dispatcher calls on its own thread.
+func serviceMain(argc, argv uintptr) uintptr {
+	h, _, _ := procRegisterServiceCtrlHandlerEx.Call(uintptr(unsafe.Pointer(svcName)), syscall.NewCallback(serviceHandler), 0)
+	if h == 0 {
+		return 0
+	}
+	svcHandle = h
+	setState(serviceStartPending)
+	commandRunner(svcCommand[0])(svcCommand[1:])
+	setState(serviceStopped)
+	return 0
+}
+
+// serviceHandler is the HandlerEx for stop, shutdown and status requests.
+func serviceHandler(control, eventType, eventData, context uintptr) uintptr {
+	switch control {
+	case serviceControlStop, serviceControlShutdown:
+		setState(serviceStopPending)
+		requestStop()
+	case serviceControlInterrogate:
+		procSetServiceStatus.Call(svcHandle, uintptr(unsafe.Pointer(&svcStatus)))
+	}
+	return 0
+}
+
+// commandRunner finds a subcommand's run function by name.
+func commandRunner(name string) func([]string) {
+	for _, c := range commandList() {
+		if c.name == name {
+			return c.run
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Package wwo classifies World Weather Online's weather codes.
+//
+// The API sends every current condition and forecast block with a numeric
+// weatherCode and an English description. Condition maps a code to its
+// description, icon, class and severity, so other programs can read those
+// the way the weather command does:
+//
+//	c, ok := wwo.Condition(302) // Moderate rain: icon "rain", class "rain", severity "moderate"
+//
+// The weather command, in package main beside this directory, draws the
+// icons and is built from these same tables.
+package wwo
+
+import "strings"
+
+// WeatherCondition describes one of the API's numeric weatherCode values.
+// Icon names the picture for it (sunny, rain, thunder, ...), which the
+// weather command draws in its -icons style by day or night; Class is the
+// coarse class: clear, cloudy, fog, rain, snow, thunder or unknown;
+// Severity is none, minor, moderate or severe, for sorting and alerting.
+type WeatherCondition struct {
+	Code        int    `json:"code"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+	Class       string `json:"class"`
+	Severity    string `json:"severity"`
+}
+
+// weatherConditions is the API's weather code list.
+var weatherConditions = []WeatherCondition{
+	{113, "Sunny", "sunny", "clear", "none"},
+	{116, "Partly cloudy", "partly cloudy", "cloudy", "none"},
+	{119, "Cloudy", "cloudy", "cloudy", "none"},
+	{122, "Overcast", "overcast", "cloudy", "none"},
+	{143, "Mist", "mist", "fog", "minor"},
+	{176, "Patchy rain nearby", "rain", "rain", "minor"},
+	{179, "Patchy snow nearby", "snow", "snow", "minor"},
+	{182, "Patchy sleet nearby", "sleet", "snow", "minor"},
+	{185, "Patchy freezing drizzle nearby", "drizzle", "rain", "moderate"},
+	{200, "Thundery outbreaks in nearby", "thunder", "thunder", "moderate"},
+	{227, "Blowing snow", "snow", "snow", "moderate"},
+	{230, "Blizzard", "blizzard", "snow", "severe"},
+	{248, "Fog", "fog", "fog", "moderate"},
+	{260, "Freezing fog", "fog", "fog", "moderate"},
+	{263, "Patchy light drizzle", "drizzle", "rain", "minor"},
+	{266, "Light drizzle", "drizzle", "rain", "minor"},
+	{281, "Freezing drizzle", "drizzle", "rain", "moderate"},
+	{284, "Heavy freezing drizzle", "drizzle", "rain", "severe"},
+	{293, "Patchy light rain", "rain", "rain", "minor"},
+	{296, "Light rain", "rain", "rain", "minor"},
+	{299, "Moderate rain at times", "rain", "rain", "moderate"},
+	{302, "Moderate rain", "rain", "rain", "moderate"},
+	{305, "Heavy rain at times", "rain", "rain", "severe"},
+	{308, "Heavy rain", "rain", "rain", "severe"},
+	{311, "Light freezing rain", "rain", "rain", "moderate"},
+	{314, "Moderate or heavy freezing rain", "rain", "rain", "severe"},
+	{317, "Light sleet", "sleet", "snow", "minor"},
+	{320, "Moderate or heavy sleet", "sleet", "snow", "moderate"},
+	{323, "Patchy light snow", "snow", "snow", "minor"},
+	{326, "Light snow", "snow", "snow", "minor"},
+	{329, "Patchy moderate snow", "snow", "snow", "moderate"},
+	{332, "Moderate snow", "snow", "snow", "moderate"},
+	{335, "Patchy heavy snow", "snow", "snow", "severe"},
+	{338, "Heavy snow", "snow", "snow", "severe"},
+	{350, "Ice pellets", "sleet", "snow", "moderate"},
+	{353, "Light rain shower", "rain", "rain", "minor"},
+	{356, "Moderate or heavy rain shower", "rain", "rain", "moderate"},
+	{359, "Torrential rain shower", "rain", "rain", "severe"},
+	{362, "Light sleet showers", "sleet", "snow", "minor"},
+	{365, "Moderate or heavy sleet showers", "sleet", "snow", "moderate"},
+	{368, "Light snow showers", "snow", "snow", "minor"},
+	{371, "Moderate or heavy snow showers", "snow", "snow", "moderate"},
+	{374, "Light showers of ice pellets", "sleet", "snow", "minor"},
+	{377, "Moderate or heavy showers of ice pellets", "sleet", "snow", "moderate"},
+	{386, "Patchy light rain in area with thunder", "thunder", "thunder", "moderate"},
+	{389, "Moderate or heavy rain in area with thunder", "thunder", "thunder", "severe"},
+	{392, "Patchy light snow in area with thunder", "thunder", "thunder", "moderate"},
+	{395, "Moderate or heavy snow in area with thunder", "thunder", "thunder", "severe"},
+}
+
+// conditionAliases are the descriptions the API has used for some codes
+// besides those in weatherConditions, lowercased.
+var conditionAliases = map[string]int{
+	"clear":                            113,
+	"patchy rain possible":             176,
+	"patchy snow possible":             179,
+	"patchy sleet possible":            182,
+	"patchy freezing drizzle possible": 185,
+	"thundery outbreaks possible":      200,
+	"thundery outbreaks in nearby":     200,
+}
+
+// conditionKeywords classify descriptions that match no code, most
+// specific first, as the API adds wordings from time to time.
+var conditionKeywords = []struct{ word, icon, class string }{
+	{"thunder", "thunder", "thunder"},
+	{"blizzard", "blizzard", "snow"},
+	{"sleet", "sleet", "snow"},
+	{"ice", "sleet", "snow"},
+	{"snow", "snow", "snow"},
+	{"drizzle", "drizzle", "rain"},
+	{"rain", "rain", "rain"},
+	{"shower", "rain", "rain"},
+	{"fog", "fog", "fog"},
+	{"mist", "mist", "fog"},
+	{"overcast", "overcast", "cloudy"},
+	{"partly cloudy", "partly cloudy", "cloudy"},
+	{"cloud", "cloudy", "cloudy"},
+	{"sunny", "sunny", "clear"},
+	{"clear", "clear", "clear"},
+}
+
+// Condition returns the description, icon and classes for a weatherCode,
+// and whether the code is one the API documents.
+func Condition(code int) (WeatherCondition, bool) {
+	for _, c := range weatherConditions {
+		if c.Code == code {
+			return c, true
+		}
+	}
+	return WeatherCondition{Code: code, Class: "unknown", Severity: "none"}, false
+}
+
+// ConditionByDescription matches an English description to its code, or
+// failing that classifies it by keyword, for responses without a
+// weatherCode.
+func ConditionByDescription(description string) WeatherCondition {
+	desc := strings.ToLower(strings.TrimSpace(description))
+	for _, c := range weatherConditions {
+		if strings.ToLower(c.Description) == desc {
+			return c
+		}
+	}
+	if code, ok := conditionAliases[desc]; ok {
+		c, _ := Condition(code)
+		return c
+	}
+	for _, k := range conditionKeywords {
+		if strings.Contains(desc, k.word) {
+			return WeatherCondition{Description: description, Icon: k.icon, Class: k.class, Severity: "none"}
+		}
+	}
+	return WeatherCondition{Description: description, Class: "unknown", Severity: "none"}
+}
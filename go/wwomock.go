@@ -0,0 +1,169 @@
+//go:build wwomock
+
+// Mock World Weather Online API for integration tests, serving the same
+// London fixtures as the wwotest package. Build it in place of the client:
+//
+//   go build -tags wwomock -o wwomock .
+//   ./wwomock -addr :8089 -latency 200ms -error-rate 0.05 -quota 500
+//   WWO_BASE_URL=http://localhost:8089/premium/v1 WWO_API_KEY=any weather -location Paris
+//
+// It answers weather, past-weather, marine, ski, search and tz requests
+// whatever the location, with the dates moved so the forecast starts today
+// and past weather on the requested date. Any key is accepted; a request
+// without one, or for -error-location, gets the API's data.error payload,
+// -error-rate of requests fail with -error-status, and a key past its
+// -quota calls for the (UTC) day gets the over-quota response. Responses
+// are JSON only.
+
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed wwotest/testdata/*.json
+var mockFixtures embed.FS
+
+func init() {
+	platformMain = runMock
+}
+
+// mockServer is the fake API. Its counters are per key and reset at UTC
+// midnight, as the real quota does.
+type mockServer struct {
+	fixtures      fs.FS
+	latency       time.Duration
+	jitter        time.Duration
+	errorRate     float64
+	errorStatus   int
+	errorLocation string
+	quota         int
+
+	mu    sync.Mutex
+	day   string
+	calls map[string]int
+}
+
+func runMock() {
+	flags := flag.NewFlagSet("wwomock", flag.ExitOnError)
+	m := &mockServer{calls: map[string]int{}}
+	addr := flags.String("addr", "127.0.0.1:8089", "Address to listen on (port 0 picks a free one, logged at startup)")
+	dir  := flags.String("fixtures", "", "Directory of <endpoint>.json bodies (weather.json, marine.json, ...) to serve instead of the built-in ones")
+	flags.DurationVar(&m.latency, "latency", 0, "Delay before every response")
+	flags.DurationVar(&m.jitter, "jitter", 0, "Up to this much extra random delay per response")
+	flags.Float64Var(&m.errorRate, "error-rate", 0, "Fraction of requests (0-1) that fail with -error-status")
+	flags.IntVar(&m.errorStatus, "error-status", http.StatusServiceUnavailable, "HTTP status of the injected failures")
+	flags.StringVar(&m.errorLocation, "error-location", "Nowhere", "Location the API always rejects as not found")
+	flags.IntVar(&m.quota, "quota", 0, "Calls allowed per key per day before the over-quota response (0 = unlimited)")
+	logFlags(flags)
+	flags.Parse(os.Args[1:])
+	setupLogging()
+
+	sub, err := fs.Sub(mockFixtures, "wwotest/testdata")
+	if err != nil {
+		fatal(err)
+	}
+	m.fixtures = sub
+	if *dir != "" {
+		m.fixtures = os.DirFS(*dir)
+	}
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fatal(err)
+	}
+	slog.Info("mock API listening", "base_url", "http://"+ln.Addr().String()+"/premium/v1")
+	fatal(http.Serve(ln, m))
+}
+
+func (m *mockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	endpoint := strings.TrimSuffix(path.Base(r.URL.Path), ".ashx")
+	slog.Info("request", "endpoint", endpoint, "q", q.Get("q")+q.Get("query"))
+
+	delay := m.latency
+	if m.jitter > 0 {
+		delay += rand.N(m.jitter)
+	}
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case q.Get("key") == "":
+		mockError(w, http.StatusOK, "There is no API key provided.")
+	case m.errorRate > 0 && rand.Float64() < m.errorRate:
+		slog.Info("injected failure", "endpoint", endpoint, "status", m.errorStatus)
+		w.WriteHeader(m.errorStatus)
+	case !m.allow(q.Get("key")):
+		mockError(w, http.StatusTooManyRequests, "API key has reached calls per day allowed limit.")
+	case strings.EqualFold(q.Get("q"), m.errorLocation) || strings.EqualFold(q.Get("query"), m.errorLocation):
+		mockError(w, http.StatusOK, "Unable to find any matching weather location to the query submitted!")
+	default:
+		body, err := fs.ReadFile(m.fixtures, endpoint+".json")
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		start := time.Now().UTC()
+		if d, err := time.Parse("2006-01-02", q.Get("date")); err == nil && endpoint == "past-weather" {
+			start = d
+		}
+		w.Write(shiftDates(body, start))
+	}
+}
+
+// allow counts a call against key and reports whether it is within the
+// day's quota.
+func (m *mockServer) allow(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if today := time.Now().UTC().Format("2006-01-02"); m.day != today {
+		m.day, m.calls = today, map[string]int{}
+	}
+	m.calls[key]++
+	return m.quota <= 0 || m.calls[key] <= m.quota
+}
+
+var fixtureDate = regexp.MustCompile(`"(\d{4}-\d{2}-\d{2})`)
+
+// shiftDates moves every date in body by the same number of days, so that
+// the first one falls on start.
+func shiftDates(body []byte, start time.Time) []byte {
+	first := fixtureDate.FindSubmatch(body)
+	if first == nil {
+		return body
+	}
+	from, err := time.Parse("2006-01-02", string(first[1]))
+	if err != nil {
+		return body
+	}
+	days := int(start.Truncate(24*time.Hour).Sub(from).Hours() / 24)
+	return fixtureDate.ReplaceAllFunc(body, func(m []byte) []byte {
+		d, err := time.Parse("2006-01-02", string(m[1:]))
+		if err != nil {
+			return m
+		}
+		return []byte(`"` + d.AddDate(0, 0, days).Format("2006-01-02"))
+	})
+}
+
+func mockError(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"data":{"error":[{"msg":%q}]}}`, msg)
+}
@@ -0,0 +1,95 @@
+// Package wwotest provides a fake World Weather Online API for tests.
+//
+// The server answers the premium v1 endpoints (weather, past-weather,
+// marine, ski, search and tz) with the canned London fixtures in testdata,
+// so code that calls the API can be exercised without a network or a key.
+// Its URL is the base URL to call; the weather command's own tests, in
+// package main beside weather.go, point the client at it with
+//
+//	srv := wwotest.NewServer(t)
+//	apiBaseURL, httpClient = srv.URL, srv.Client()
+//	data, err := fetchWeather(context.Background(), "London", 3, 3, "test-key")
+//
+// and anything else that talks to the API takes srv.URL as its base URL,
+// as the weather command does from WWO_BASE_URL. A request without a key,
+// or for the location wwotest.ErrorLocation, gets the API's data.error
+// payload, and every request is recorded for assertions. For tests that
+// aren't written in Go, wwomock.go builds the same fixtures into a
+// standalone server.
+package wwotest
+
+import (
+	"embed"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//go:embed testdata/*.json
+var fixtures embed.FS
+
+// ErrorLocation is a q value the fake server always rejects with an API
+// error, to exercise the error path.
+const ErrorLocation = "Nowhere"
+
+// Server is a running fake API. URL is the base URL to use in place of
+// https://api.worldweatheronline.com/premium/v1.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []url.Values
+}
+
+// NewServer starts a fake API that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Requests returns the query parameters of every request served so far.
+func (s *Server) Requests() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]url.Values(nil), s.requests...)
+}
+
+// Fixture returns the canned response body for an endpoint such as
+// "weather" or "marine".
+func Fixture(endpoint string) ([]byte, error) {
+	return fixtures.ReadFile("testdata/" + endpoint + ".json")
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	s.requests = append(s.requests, q)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case q.Get("key") == "":
+		writeError(w, "There is no API key provided.")
+		return
+	case strings.EqualFold(q.Get("q"), ErrorLocation) || strings.EqualFold(q.Get("query"), ErrorLocation):
+		writeError(w, "Unable to find any matching weather location to the query submitted!")
+		return
+	}
+
+	endpoint := strings.TrimSuffix(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:], ".ashx")
+	body, err := Fixture(endpoint)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(body)
+}
+
+func writeError(w http.ResponseWriter, msg string) {
+	w.Write([]byte(`{"data":{"error":[{"msg":"` + msg + `"}]}}`))
+}